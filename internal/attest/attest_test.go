@@ -0,0 +1,155 @@
+// Copyright 2022 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeSigner returns a fixed signature, recording the data it was asked to
+// sign so tests can assert on the DSSE pre-authentication encoding.
+type fakeSigner struct {
+	signed []byte
+}
+
+func (f *fakeSigner) Sign(data []byte) ([]byte, string, error) {
+	f.signed = data
+	return []byte("fake-signature"), "fake-key-id", nil
+}
+
+func TestNewEnvelope(t *testing.T) {
+	stmt := NewCommitStatement("deadbeef", "https://example.com/predicate/v1", json.RawMessage(`{"foo":"bar"}`))
+
+	signer := &fakeSigner{}
+	env, err := NewEnvelope(stmt, signer)
+	if err != nil {
+		t.Fatalf("NewEnvelope() error = %v", err)
+	}
+
+	if env.PayloadType != payloadType {
+		t.Errorf("PayloadType = %q, want %q", env.PayloadType, payloadType)
+	}
+	if len(env.Signatures) != 1 || env.Signatures[0].KeyID != "fake-key-id" {
+		t.Fatalf("unexpected signatures: %+v", env.Signatures)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		t.Fatalf("error decoding payload: %v", err)
+	}
+
+	var gotStmt Statement
+	if err := json.Unmarshal(payload, &gotStmt); err != nil {
+		t.Fatalf("error unmarshaling payload: %v", err)
+	}
+	if gotStmt.Subject[0].Name != "deadbeef" {
+		t.Errorf("subject name = %q, want %q", gotStmt.Subject[0].Name, "deadbeef")
+	}
+
+	wantPAE := preAuthEncode(payloadType, payload)
+	if !bytes.Equal(signer.signed, wantPAE) {
+		t.Errorf("signer was given %q, want PAE %q", signer.signed, wantPAE)
+	}
+}
+
+func TestResolvePredicateType(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"slsaprovenance", "https://slsa.dev/provenance/v0.2"},
+		{"spdx", "https://spdx.dev/Document"},
+		{"custom", "custom"},
+		{"https://example.com/predicate/v1", "https://example.com/predicate/v1"},
+	}
+	for _, tt := range tests {
+		if got := ResolvePredicateType(tt.in); got != tt.want {
+			t.Errorf("ResolvePredicateType(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStore_GitNote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(cmd.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+		return string(out)
+	}
+
+	run("init", "-q")
+	run("commit", "-q", "--allow-empty", "-m", "init")
+	sha := strings.TrimSpace(run("rev-parse", "HEAD"))
+
+	stmt := NewCommitStatement(sha, "https://example.com/predicate/v1", json.RawMessage(`{}`))
+	env, err := NewEnvelope(stmt, &fakeSigner{})
+	if err != nil {
+		t.Fatalf("NewEnvelope() error = %v", err)
+	}
+
+	olddir := mustChdir(t, dir)
+	defer mustChdir(t, olddir)
+
+	if err := Store("git-note", sha, "test", "test@example.com", env); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	note := run("notes", "--ref", gitNotesRefPrefix+sha, "show", sha)
+
+	var gotEnv Envelope
+	if err := json.Unmarshal([]byte(note), &gotEnv); err != nil {
+		t.Fatalf("error unmarshaling stored note: %v", err)
+	}
+	if gotEnv.Payload != env.Payload {
+		t.Errorf("stored payload = %q, want %q", gotEnv.Payload, env.Payload)
+	}
+}
+
+func TestStore_UnknownOutput(t *testing.T) {
+	env := &Envelope{PayloadType: payloadType}
+	if err := Store("carrier-pigeon", "deadbeef", "", "", env); err == nil {
+		t.Fatal("expected error for unknown gitsign.attestOutput")
+	}
+}
+
+func mustChdir(t *testing.T, dir string) string {
+	t.Helper()
+	old, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("error getting working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("error changing directory to %s: %v", dir, err)
+	}
+	return old
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// payloadType identifies the content of a DSSE envelope's payload as an
+// in-toto statement. See
+// https://github.com/secure-systems-lab/dsse/blob/master/envelope.md.
+const payloadType = "application/vnd.in-toto+json"
+
+// Signer produces a signature over the exact bytes it's given (the DSSE
+// pre-authentication encoding of the payload), returning the raw signature
+// and an identifier for the key used.
+type Signer interface {
+	Sign(data []byte) (sig []byte, keyID string, err error)
+}
+
+// Envelope is a DSSE envelope: a typed, base64-encoded payload plus one or
+// more signatures over it.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a single DSSE signature entry.
+type Signature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   string `json:"sig"`
+}
+
+// NewEnvelope marshals stmt, signs its DSSE pre-authentication encoding with
+// signer, and returns the resulting envelope.
+func NewEnvelope(stmt *Statement, signer Signer) (*Envelope, error) {
+	payload, err := json.Marshal(stmt)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling statement: %w", err)
+	}
+
+	pae := preAuthEncode(payloadType, payload)
+	sig, keyID, err := signer.Sign(pae)
+	if err != nil {
+		return nil, fmt.Errorf("error signing attestation: %w", err)
+	}
+
+	return &Envelope{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []Signature{
+			{KeyID: keyID, Sig: base64.StdEncoding.EncodeToString(sig)},
+		},
+	}, nil
+}
+
+// preAuthEncode implements the DSSE PAE(type, body) construction:
+// "DSSEv1" SP LEN(type) SP type SP LEN(body) SP body
+func preAuthEncode(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s",
+		len(payloadType), payloadType, len(payload), payload))
+}
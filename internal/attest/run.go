@@ -0,0 +1,60 @@
+// Copyright 2022 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attest
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sabre1041/gitsign/internal/config"
+)
+
+// Options holds the inputs to a single `gitsign attest` invocation, beyond
+// what's already resolved in the gitsign config.
+type Options struct {
+	// CommitSHA is the commit the attestation is about.
+	CommitSHA string
+	// PredicateType overrides cfg.Attest.PredicateType for this invocation.
+	// If empty, cfg.Attest.PredicateType is used.
+	PredicateType string
+	// Predicate is the raw (already-marshaled) predicate JSON to attest to.
+	Predicate json.RawMessage
+}
+
+// Run builds an in-toto statement for opts, wraps it in a DSSE envelope
+// signed by signer, and stores it per cfg.Attest.Output. This is the
+// implementation behind the `gitsign attest` command.
+func Run(cfg *config.Config, signer Signer, opts Options) error {
+	if opts.CommitSHA == "" {
+		return fmt.Errorf("commit SHA is required")
+	}
+
+	predicateType := opts.PredicateType
+	if predicateType == "" {
+		predicateType = cfg.Attest.PredicateType
+	}
+	if predicateType == "" {
+		return fmt.Errorf("gitsign.attestPredicateType (or -predicate-type) is required")
+	}
+
+	stmt := NewCommitStatement(opts.CommitSHA, ResolvePredicateType(predicateType), opts.Predicate)
+
+	env, err := NewEnvelope(stmt, signer)
+	if err != nil {
+		return err
+	}
+
+	return Store(cfg.Attest.Output, opts.CommitSHA, cfg.CommitterName, cfg.CommitterEmail, env)
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package attest implements the `gitsign attest` verb: building an in-toto
+// statement over a commit, wrapping it in a DSSE envelope, and storing the
+// envelope per the configured gitsign.attestOutput.
+package attest
+
+import "encoding/json"
+
+// statementType is the in-toto Statement type URI. See
+// https://github.com/in-toto/attestation/blob/main/spec/v1/statement.md.
+const statementType = "https://in-toto.io/Statement/v1"
+
+// Subject identifies the artifact an attestation is about, by digest.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Statement is an in-toto v1 attestation statement: a typed predicate about
+// one or more subjects.
+type Statement struct {
+	Type          string          `json:"_type"`
+	Subject       []Subject       `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// predicateTypeAliases maps the short names documented on
+// config.AttestConfig.PredicateType to their full in-toto predicate type
+// URIs. "custom" is deliberately absent: it has no fixed URI, since it
+// stands for whatever predicate type URI the caller supplies directly.
+var predicateTypeAliases = map[string]string{
+	"slsaprovenance": "https://slsa.dev/provenance/v0.2",
+	"spdx":           "https://spdx.dev/Document",
+}
+
+// ResolvePredicateType expands a known short name (see predicateTypeAliases)
+// to its full in-toto predicate type URI. Any other value, including a
+// custom predicate type URI supplied directly via
+// gitsign.attestPredicateType, is returned unchanged.
+func ResolvePredicateType(predicateType string) string {
+	if uri, ok := predicateTypeAliases[predicateType]; ok {
+		return uri
+	}
+	return predicateType
+}
+
+// NewCommitStatement builds a Statement whose subject is the given commit
+// SHA, carrying predicate (already-marshaled JSON) under predicateType.
+func NewCommitStatement(commitSHA, predicateType string, predicate json.RawMessage) *Statement {
+	return &Statement{
+		Type: statementType,
+		Subject: []Subject{
+			{
+				Name:   commitSHA,
+				Digest: map[string]string{"gitCommit": commitSHA},
+			},
+		},
+		PredicateType: predicateType,
+		Predicate:     predicate,
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// gitNotesRefPrefix mirrors the gitsign.attestOutput=git-note doc comment on
+// config.AttestConfig.Output: each commit's envelope gets its own notes ref,
+// rather than sharing the default refs/notes/commits ref, so attestations
+// can be pushed/fetched independently of regular notes.
+const gitNotesRefPrefix = "refs/notes/gitsign-attestations/"
+
+// Store persists env for commitSHA according to output (one of the
+// gitsign.attestOutput values: "rekor" or "git-note"). committerName/Email
+// are used as the git-note author identity when both are non-empty, matching
+// Config.CommitterName/CommitterEmail (read from user.name/user.email).
+func Store(output, commitSHA, committerName, committerEmail string, env *Envelope) error {
+	b, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("error marshaling attestation envelope: %w", err)
+	}
+
+	switch output {
+	case "git-note", "":
+		return storeGitNote(commitSHA, committerName, committerEmail, b)
+	case "rekor":
+		return storeRekor(commitSHA, b)
+	default:
+		return fmt.Errorf("unknown gitsign.attestOutput %q: must be one of [rekor, git-note]", output)
+	}
+}
+
+// storeGitNote writes the DSSE envelope as the sole note content on
+// refs/notes/gitsign-attestations/<commitSHA>, attached to the commit
+// object itself.
+func storeGitNote(commitSHA, committerName, committerEmail string, envelope []byte) error {
+	ref := gitNotesRefPrefix + commitSHA
+
+	cmd := exec.Command("git", "notes", "--ref", ref, "add", "-f", "-F", "-", commitSHA)
+	cmd.Stdin = bytes.NewReader(envelope)
+	if committerName != "" && committerEmail != "" {
+		cmd.Env = append(cmd.Environ(),
+			"GIT_AUTHOR_NAME="+committerName, "GIT_AUTHOR_EMAIL="+committerEmail,
+			"GIT_COMMITTER_NAME="+committerName, "GIT_COMMITTER_EMAIL="+committerEmail,
+		)
+	}
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error writing git note %s: %w: %s", ref, err, stderr)
+	}
+	return nil
+}
+
+// storeRekor would upload the DSSE envelope to a Rekor transparency log as
+// an intoto entry. gitsign doesn't vendor a Rekor client in this tree yet,
+// so this is the one remaining gap in the gitsign.attestOutput story -
+// gitsign.attestOutput=git-note is fully supported.
+func storeRekor(_ string, _ []byte) error {
+	return fmt.Errorf("gitsign.attestOutput=rekor is not yet implemented; use git-note")
+}
@@ -17,10 +17,13 @@ package config
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -31,43 +34,195 @@ const (
 	RekorVerificationOffline
 )
 
+// SigningBackend identifies the mechanism gitsign uses to produce a
+// signature - either a Fulcio keyless flow (over REST or gRPC) or a
+// long-lived key held in a KMS.
+type SigningBackend string
+
+const (
+	SigningBackendFulcioREST SigningBackend = "fulcio-rest"
+	SigningBackendFulcioGRPC SigningBackend = "fulcio-grpc"
+	SigningBackendKMS        SigningBackend = "kms"
+)
+
 var (
 	// execFn is a function to get the raw git config.
 	// Configurable to allow for overriding for testing.
 	execFn = realExec
+	// repoRootFn resolves the root directory of the current git repository.
+	// Configurable to allow for overriding for testing.
+	repoRootFn = gitRepoRoot
 )
 
-// Config represents configuration options for gitsign.
-type Config struct {
-	// Address of Fulcio server
-	Fulcio string
+// FulcioConfig holds options for talking to a Fulcio instance to obtain a
+// signing certificate.
+type FulcioConfig struct {
+	// Address of Fulcio server.
+	URL string
 	// Path to PEM encoded certificate root for Fulcio.
-	FulcioRoot string
+	Root string
+	// Protocol to use to talk to Fulcio. One of [rest, grpc] (default: rest).
+	// If unset, this is inferred from the scheme of URL (e.g. grpcs://
+	// implies grpc).
+	Protocol string
+}
 
-	// Address of Rekor server
-	Rekor string
+// RekorConfig holds options for recording (and later verifying) signatures
+// in a Rekor transparency log.
+type RekorConfig struct {
+	// Address of Rekor server.
+	URL string
 	// Rekor storage mode to operate in. One of [online, offline] (default: online)
 	// online - Commit SHAs are stored in Rekor, requiring online verification for all commit objects.
 	// offline - Hashed commit content is stored in Rekor, with Rekor attributes
 	// necessary for offline verification being stored in the commit itself.
 	// Note: online verification will be deprecated in favor of offline in the future.
-	RekorMode string
+	Mode string
+}
 
-	// OIDC client ID for application
+// VerificationMode returns the RekorConfig's Mode as a RekorVerificationMode,
+// defaulting to online for unrecognized values.
+func (c RekorConfig) VerificationMode() RekorVerificationMode {
+	if strings.EqualFold(c.Mode, "offline") {
+		return RekorVerificationOffline
+	}
+	return RekorVerificationOnline
+}
+
+// OIDCConfig holds options for obtaining an OIDC identity token used to
+// request a Fulcio signing certificate.
+type OIDCConfig struct {
+	// OIDC client ID for application.
 	ClientID string
-	// OIDC Redirect URL
+	// OIDC Redirect URL.
 	RedirectURL string
-	// OIDC provider to be used to issue ID token
+	// OIDC provider to be used to issue ID token.
 	Issuer string
+	// Additional OIDC issuers to accept as valid alongside Issuer above, e.g.
+	// while migrating between identity providers. Populated from a
+	// list-valued gitsign.oidcIssuers.
+	Issuers []string
 	// Optional Connector ID to use when fetching Dex OIDC token.
 	// See https://github.com/sigstore/sigstore/blob/c645ceb9d075499f3a4b3f183d3a6864640fa956/pkg/oauthflow/flow.go#L49-L53
 	// for more details.
 	ConnectorID string
+}
+
+// TSAConfig holds options for obtaining (and embedding) a trusted timestamp
+// from an RFC3161 Timestamp Authority.
+type TSAConfig struct {
+	// Timestamp Authority address to use to get a trusted timestamp.
+	URL string
+	// Additional Timestamp Authority addresses to try, in order, if URL
+	// doesn't respond - for redundancy across multiple TSAs. Populated from
+	// a list-valued gitsign.timestampServerURLs.
+	URLs []string
+	// Timestamp Authority PEM encoded cert(s) to use for verification. Only
+	// used when TrustedRoot.Path is unset: the TSA chain in the TrustedRoot
+	// bundle takes precedence (see Config.ResolveTSACertChain).
+	CertChain string
+	// Timestamp handling mode to use. One of [online, embed, verify-embedded]
+	// (default: online).
+	// online - the TSA is contacted at signing and verification time.
+	// embed and verify-embedded are checked for their prerequisites
+	// (embed: gitsign.rekorMode=offline and a TSA URL; verify-embedded: a
+	// TSA cert chain or trusted root) and then rejected by Get: no RFC3161
+	// token is embedded into or read from the commit signature.
+	Mode string
+}
+
+// PolicyConfig holds options that constrain what gitsign considers a valid
+// signature, beyond cryptographic validity.
+type PolicyConfig struct {
+	// Require that the Git committer identity matches the identity in the
+	// Fulcio certificate.
+	MatchCommitter bool
+}
+
+// KMSConfig holds options for signing with a long-lived key held in a KMS,
+// as an alternative to the Fulcio OIDC flow.
+type KMSConfig struct {
+	// KMS key URI to use instead of the Fulcio OIDC flow, e.g.
+	// awskms://, gcpkms://, azurekms://, hashivault://, pkcs11://.
+	// Setting it makes Get return an error once CertChain is checked: there
+	// is no KMS signer, so the OIDC/Fulcio flow is not skipped and nothing
+	// is signed with the KMS-held key.
+	Key string
+	// Path to the PEM encoded certificate chain for the KMS key above.
+	// Required when Key is set, since KMS keys have no Fulcio-issued
+	// certificate to embed in the signature.
+	CertChain string
+}
+
+// AttestConfig holds options for generating in-toto attestations via
+// `gitsign attest`, as an alternative to signing a commit directly. The
+// statement-building, DSSE-wrapping, and storage logic lives in
+// internal/attest; this struct only carries the options it's configured by.
+type AttestConfig struct {
+	// Predicate type to use when generating an attestation. One of a known
+	// short name - slsaprovenance (expands to
+	// https://slsa.dev/provenance/v0.2) or spdx (expands to
+	// https://spdx.dev/Document), see internal/attest.ResolvePredicateType -
+	// or a full in-toto predicate type URI. "custom" is not a short name:
+	// there's no fixed URI for it, so pass the full predicate type URI
+	// directly for anything not covered by the known short names.
+	PredicateType string
+	// Where to store generated attestations. One of [rekor, git-note]
+	// (default: git-note). git-note stores the DSSE envelope as a git note
+	// under refs/notes/gitsign-attestations/<commit-sha>; rekor is not yet
+	// implemented (see internal/attest.Store), so it isn't the default.
+	Output string
+}
+
+// HTTPConfig holds options for the HTTP transport used for all outbound
+// calls to Fulcio/Rekor/TSA/OIDC.
+type HTTPConfig struct {
+	// Path to a PEM encoded CA bundle to trust in addition to the system
+	// root CAs. Useful for corporate MITM proxies.
+	CABundle string
+	// Path to a PEM encoded client certificate to present for mTLS, e.g.
+	// when talking to a private Sigstore deployment behind a mutual-TLS
+	// gateway. Must be set together with MTLSKey.
+	MTLSCert string
+	// Path to the PEM encoded private key matching MTLSCert.
+	MTLSKey string
+}
+
+// TrustedRootConfig holds options for sourcing a single Sigstore trusted
+// root bundle (Fulcio roots, Rekor keys, CT log keys, TSA chains) instead of
+// configuring each individually.
+type TrustedRootConfig struct {
+	// Path to a Sigstore trusted root JSON file (as produced by TUF via
+	// cosign/sigstore-go). When set, Config.ResolveFulcioRoot and
+	// Config.ResolveTSACertChain prefer the PEM material in this bundle over
+	// the individually configured Fulcio.Root/TSA.CertChain.
+	Path string
+	// TUF mirror URL. Must be set together with TUFRoot. Setting it makes Get
+	// return an error: no trusted root is fetched or cached from a TUF
+	// mirror.
+	TUFMirror string
+	// TUF root.json used to initialize trust in the TUF mirror above. Must
+	// be set together with TUFMirror.
+	TUFRoot string
+}
+
+// Config represents configuration options for gitsign.
+type Config struct {
+	Fulcio      FulcioConfig
+	Rekor       RekorConfig
+	OIDC        OIDCConfig
+	TSA         TSAConfig
+	Policy      PolicyConfig
+	KMS         KMSConfig
+	Attest      AttestConfig
+	TrustedRoot TrustedRootConfig
+	HTTP        HTTPConfig
 
-	// Timestamp Authority address to use to get a trusted timestamp
-	TimestampURL string
-	// Timestamp Authority PEM encoded cert(s) to use for verification.
-	TimestampCert string
+	// Signing backend to use to produce commit signatures. One of
+	// [fulcio-rest, fulcio-grpc, kms] (default: fulcio-rest). Get returns an
+	// error for fulcio-grpc, since there is no Fulcio v2 gRPC client, and
+	// for any gitsign.kms key (see KMSConfig.Key).
+	SigningBackend SigningBackend
 
 	// Path to log status output. Helpful for debugging when no TTY is available in the environment.
 	LogPath string
@@ -75,56 +230,280 @@ type Config struct {
 	// Committer details
 	CommitterName  string
 	CommitterEmail string
-	MatchCommitter bool
+}
+
+// Option overrides a Config value after all other layers have been applied.
+// This is the command-line override layer - e.g. the gitsign CLI binds flags
+// to Options so that `--fulcio-url` wins over everything else.
+type Option func(*Config)
+
+// defaultConfig returns the built-in default configuration, before any git
+// config, config file, env var, or command-line layers are applied.
+func defaultConfig() *Config {
+	return &Config{
+		Fulcio: FulcioConfig{
+			URL: "https://fulcio.sigstore.dev",
+		},
+		Rekor: RekorConfig{
+			URL: "https://rekor.sigstore.dev",
+			// TODO: default to offline
+			Mode: "online",
+		},
+		OIDC: OIDCConfig{
+			ClientID: "sigstore",
+			Issuer:   "https://oauth2.sigstore.dev/auth",
+		},
+		TSA: TSAConfig{
+			Mode: "online",
+		},
+		Attest: AttestConfig{
+			Output: "git-note",
+		},
+		// Left unset so applyEnv can tell an explicit choice (from git
+		// config, file config, or env) apart from "nothing chose a backend
+		// yet" and only infer grpc/kms in the latter case. Resolved to
+		// SigningBackendFulcioREST at the end of applyEnv if still unset.
+		SigningBackend: "",
+	}
 }
 
 // Get fetches the gitsign config options for the repo in the current working
 // directory.
-func Get() (*Config, error) {
+//
+// Config is resolved in increasing order of precedence: built-in defaults,
+// system/global/repo git config (merged by git itself, repo wins), a
+// .gitsign.yaml/.gitsign.toml file at the repo root, environment variables,
+// and finally any command-line Options passed in.
+func Get(overrides ...Option) (*Config, error) {
+	out := defaultConfig()
+
 	r, err := execFn()
 	if err != nil {
 		return nil, fmt.Errorf("error reading config: %w", err)
 	}
-	cfg := parseConfig(r)
+	applyOptionMap(out, parseConfig(r))
 
-	// Start with default config
-	out := &Config{
-		Fulcio:   "https://fulcio.sigstore.dev",
-		Rekor:    "https://rekor.sigstore.dev",
-		ClientID: "sigstore",
-		Issuer:   "https://oauth2.sigstore.dev/auth",
-		// TODO: default to offline
-		RekorMode: "online",
+	if err := loadFileConfig(out); err != nil {
+		return nil, err
 	}
 
-	// Get values from config file.
-	applyGitOptions(out, cfg)
+	applyEnv(out)
 
-	// Get values from env vars.
+	for _, opt := range overrides {
+		opt(out)
+	}
 
-	// Same as GITSIGN_FULCIO_ROOT, but using legacy cosign value for compatibility.
+	if err := validate(out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// applyEnv applies environment variable overrides onto an already
+// git-config/file-config populated Config.
+func applyEnv(out *Config) {
+	// Same as SIGSTORE_ROOT_FILE, but using legacy cosign value for compatibility.
 	// Long term we're likely going to be moving away from this.
 	// See https://github.com/sigstore/sigstore/pull/759 for more discussion.
-	out.FulcioRoot = envOrValue("SIGSTORE_ROOT_FILE", out.FulcioRoot)
+	out.Fulcio.Root = envOrValue("SIGSTORE_ROOT_FILE", out.Fulcio.Root)
 
 	// Check for common environment variables that could be shared with other
 	// Sigstore tools. Gitsign envs should take precedence.
 	for _, prefix := range []string{"SIGSTORE", "GITSIGN"} {
-		out.Fulcio = envOrValue(fmt.Sprintf("%s_FULCIO_URL", prefix), out.Fulcio)
-		out.FulcioRoot = envOrValue(fmt.Sprintf("%s_FULCIO_ROOT", prefix), out.FulcioRoot)
-		out.Rekor = envOrValue(fmt.Sprintf("%s_REKOR_URL", prefix), out.Rekor)
-		out.ClientID = envOrValue(fmt.Sprintf("%s_OIDC_CLIENT_ID", prefix), out.ClientID)
-		out.RedirectURL = envOrValue(fmt.Sprintf("%s_OIDC_REDIRECT_URL", prefix), out.RedirectURL)
-		out.Issuer = envOrValue(fmt.Sprintf("%s_OIDC_ISSUER", prefix), out.Issuer)
-		out.ConnectorID = envOrValue(fmt.Sprintf("%s_CONNECTOR_ID", prefix), out.ConnectorID)
-		out.TimestampURL = envOrValue(fmt.Sprintf("%s_TIMESTAMP_SERVER_URL", prefix), out.TimestampURL)
-		out.TimestampCert = envOrValue(fmt.Sprintf("%s_TIMESTAMP_CERT_CHAIN", prefix), out.TimestampCert)
+		out.Fulcio.URL = envOrValue(fmt.Sprintf("%s_FULCIO_URL", prefix), out.Fulcio.URL)
+		out.Fulcio.Root = envOrValue(fmt.Sprintf("%s_FULCIO_ROOT", prefix), out.Fulcio.Root)
+		out.Rekor.URL = envOrValue(fmt.Sprintf("%s_REKOR_URL", prefix), out.Rekor.URL)
+		out.OIDC.ClientID = envOrValue(fmt.Sprintf("%s_OIDC_CLIENT_ID", prefix), out.OIDC.ClientID)
+		out.OIDC.RedirectURL = envOrValue(fmt.Sprintf("%s_OIDC_REDIRECT_URL", prefix), out.OIDC.RedirectURL)
+		out.OIDC.Issuer = envOrValue(fmt.Sprintf("%s_OIDC_ISSUER", prefix), out.OIDC.Issuer)
+		out.OIDC.ConnectorID = envOrValue(fmt.Sprintf("%s_CONNECTOR_ID", prefix), out.OIDC.ConnectorID)
+		out.TSA.URL = envOrValue(fmt.Sprintf("%s_TIMESTAMP_SERVER_URL", prefix), out.TSA.URL)
+		out.TSA.CertChain = envOrValue(fmt.Sprintf("%s_TIMESTAMP_CERT_CHAIN", prefix), out.TSA.CertChain)
 	}
 
 	out.LogPath = envOrValue("GITSIGN_LOG", out.LogPath)
-	out.RekorMode = envOrValue("GITSIGN_REKOR_MODE", out.RekorMode)
+	out.Rekor.Mode = envOrValue("GITSIGN_REKOR_MODE", out.Rekor.Mode)
+	out.TrustedRoot.Path = envOrValue("GITSIGN_TRUSTED_ROOT", out.TrustedRoot.Path)
+	out.Fulcio.Protocol = envOrValue("GITSIGN_FULCIO_PROTOCOL", out.Fulcio.Protocol)
+	out.SigningBackend = SigningBackend(envOrValue("GITSIGN_SIGNING_BACKEND", string(out.SigningBackend)))
+	out.KMS.Key = envOrValue("GITSIGN_KMS_KEY", out.KMS.Key)
+	out.TSA.Mode = envOrValue("GITSIGN_TIMESTAMP_MODE", out.TSA.Mode)
 
-	return out, nil
+	// If nothing (git config, file config, or env) has explicitly chosen a
+	// signing backend yet, infer whether to use Fulcio's gRPC API or KMS
+	// from the rest of the resolved config. An explicit
+	// gitsign.signingBackend = fulcio-rest is left alone, since out.SigningBackend
+	// is non-empty in that case.
+	if out.SigningBackend == "" {
+		if out.Fulcio.Protocol == "grpc" ||
+			strings.HasPrefix(out.Fulcio.URL, "grpcs://") || strings.HasPrefix(out.Fulcio.URL, "grpc://") {
+			out.SigningBackend = SigningBackendFulcioGRPC
+		}
+		if out.KMS.Key != "" {
+			out.SigningBackend = SigningBackendKMS
+		}
+	}
+
+	// Still nothing chose a backend: fall back to the default.
+	if out.SigningBackend == "" {
+		out.SigningBackend = SigningBackendFulcioREST
+	}
+}
+
+// validate checks that the resolved Config is internally consistent,
+// returning a descriptive error for the first problem found.
+func validate(cfg *Config) error {
+	type field struct {
+		name  string
+		value string
+	}
+
+	for _, f := range []field{
+		{"gitsign.fulcio", cfg.Fulcio.URL},
+		{"gitsign.rekor", cfg.Rekor.URL},
+		{"gitsign.timestampServerURL", cfg.TSA.URL},
+		{"gitsign.issuer", cfg.OIDC.Issuer},
+		{"gitsign.redirectURL", cfg.OIDC.RedirectURL},
+	} {
+		if f.value == "" {
+			continue
+		}
+		if _, err := url.Parse(f.value); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", f.name, f.value, err)
+		}
+	}
+
+	for _, u := range cfg.OIDC.Issuers {
+		if _, err := url.Parse(u); err != nil {
+			return fmt.Errorf("invalid gitsign.oidcIssuers entry %q: %w", u, err)
+		}
+	}
+
+	for _, u := range cfg.TSA.URLs {
+		if _, err := url.Parse(u); err != nil {
+			return fmt.Errorf("invalid gitsign.timestampServerURLs entry %q: %w", u, err)
+		}
+	}
+
+	switch strings.ToLower(cfg.Rekor.Mode) {
+	case "online", "offline":
+	default:
+		return fmt.Errorf("invalid gitsign.rekorMode %q: must be one of [online, offline]", cfg.Rekor.Mode)
+	}
+
+	timestampMode := strings.ToLower(cfg.TSA.Mode)
+	switch timestampMode {
+	case "online", "embed", "verify-embedded":
+	default:
+		return fmt.Errorf("invalid gitsign.timestampMode %q: must be one of [online, embed, verify-embedded]", cfg.TSA.Mode)
+	}
+
+	if timestampMode == "embed" {
+		// Embedding a timestamp token only makes sense alongside an offline
+		// Rekor bundle (online Rekor entries already carry their own
+		// verifiable timestamp), and requires a TSA to actually fetch a
+		// token from at signing time.
+		if cfg.Rekor.VerificationMode() != RekorVerificationOffline {
+			return errors.New("gitsign.timestampMode=embed requires gitsign.rekorMode=offline")
+		}
+		if cfg.TSA.URL == "" {
+			return errors.New("gitsign.timestampMode=embed requires gitsign.timestampServerURL to be set")
+		}
+	}
+
+	if timestampMode == "verify-embedded" && cfg.TSA.CertChain == "" && cfg.TrustedRoot.Path == "" {
+		return errors.New("gitsign.timestampMode=verify-embedded requires gitsign.timestampCertChain or gitsign.trustedRoot to verify embedded tokens")
+	}
+
+	// gitsign doesn't embed or verify RFC3161 tokens as an unsigned CMS
+	// attribute anywhere in this tree yet, so these modes would silently do
+	// nothing differently from gitsign.timestampMode=online - fail loudly
+	// instead (mirrors the gitsign.tufMirror stub below).
+	if timestampMode == "embed" || timestampMode == "verify-embedded" {
+		return fmt.Errorf("gitsign.timestampMode=%s is not yet implemented (no CMS-attribute embedding/verification support in this tree); use gitsign.timestampMode=online", cfg.TSA.Mode)
+	}
+
+	switch cfg.SigningBackend {
+	case SigningBackendFulcioREST, SigningBackendFulcioGRPC, SigningBackendKMS:
+	default:
+		return fmt.Errorf("invalid gitsign.signingBackend %q: must be one of [%s, %s, %s]", cfg.SigningBackend, SigningBackendFulcioREST, SigningBackendFulcioGRPC, SigningBackendKMS)
+	}
+
+	// Only the REST Fulcio backend is actually wired up in this tree: there's
+	// no Fulcio v2 gRPC client behind this enum value, so selecting it would
+	// silently behave like fulcio-rest. Fail loudly instead (mirrors the
+	// gitsign.tufMirror stub below).
+	if cfg.SigningBackend == SigningBackendFulcioGRPC {
+		return fmt.Errorf("gitsign.signingBackend=%s is not yet implemented (no Fulcio v2 gRPC client in this tree); use gitsign.signingBackend=%s", SigningBackendFulcioGRPC, SigningBackendFulcioREST)
+	}
+
+	switch strings.ToLower(cfg.Fulcio.Protocol) {
+	case "", "rest", "grpc":
+	default:
+		return fmt.Errorf("invalid gitsign.fulcioProtocol %q: must be one of [rest, grpc]", cfg.Fulcio.Protocol)
+	}
+
+	for _, f := range []field{
+		{"gitsign.fulcioRoot", cfg.Fulcio.Root},
+		{"gitsign.timestampCertChain", cfg.TSA.CertChain},
+		{"gitsign.kmsCertChain", cfg.KMS.CertChain},
+		{"gitsign.caBundle", cfg.HTTP.CABundle},
+		{"gitsign.mtlsCert", cfg.HTTP.MTLSCert},
+		{"gitsign.mtlsKey", cfg.HTTP.MTLSKey},
+		{"gitsign.trustedRoot", cfg.TrustedRoot.Path},
+	} {
+		if f.value == "" {
+			continue
+		}
+		fh, err := os.Open(f.value)
+		if err != nil {
+			return fmt.Errorf("error loading %s: %w", f.name, err)
+		}
+		fh.Close()
+	}
+
+	if cfg.KMS.Key != "" && cfg.KMS.CertChain == "" {
+		return errors.New("gitsign.kmsCertChain is required when gitsign.kms is set")
+	}
+
+	// There's no KMS client or CMS signer behind gitsign.kms in this tree -
+	// gitsign would not actually skip the OIDC/Fulcio flow as documented.
+	// Fail loudly instead of silently ignoring it (mirrors the
+	// gitsign.tufMirror stub below).
+	if cfg.KMS.Key != "" {
+		return fmt.Errorf("gitsign.kms is not yet implemented (no KMS client/CMS signer in this tree); use gitsign.signingBackend=%s with Fulcio keyless signing instead", SigningBackendFulcioREST)
+	}
+
+	if (cfg.HTTP.MTLSCert == "") != (cfg.HTTP.MTLSKey == "") {
+		return errors.New("gitsign.mtlsCert and gitsign.mtlsKey must both be set")
+	}
+
+	if cfg.TrustedRoot.Path != "" {
+		if _, err := LoadTrustedRootBundle(cfg.TrustedRoot.Path); err != nil {
+			return fmt.Errorf("error loading gitsign.trustedRoot: %w", err)
+		}
+	}
+
+	if (cfg.TrustedRoot.TUFMirror == "") != (cfg.TrustedRoot.TUFRoot == "") {
+		return errors.New("gitsign.tufMirror and gitsign.tufRoot must both be set")
+	}
+
+	// gitsign doesn't vendor a TUF client in this tree yet, so there's
+	// nothing that actually fetches/caches a trusted root from tufMirror -
+	// fail loudly here rather than silently accepting the config and never
+	// using it (mirrors storeRekor's "not yet implemented" stub).
+	if cfg.TrustedRoot.TUFMirror != "" {
+		return fmt.Errorf("gitsign.tufMirror/gitsign.tufRoot (TUF-backed trusted root fetch) is not yet implemented; use gitsign.trustedRoot with a pre-fetched trusted_root.json instead")
+	}
+
+	switch cfg.Attest.Output {
+	case "", "rekor", "git-note":
+	default:
+		return fmt.Errorf("invalid gitsign.attestOutput %q: must be one of [rekor, git-note]", cfg.Attest.Output)
+	}
+
+	return nil
 }
 
 // realExec forks out to the git binary to read the git config.
@@ -150,8 +529,15 @@ func realExec() (io.Reader, error) {
 	return stdout, nil
 }
 
-func parseConfig(r io.Reader) map[string]string {
-	out := map[string]string{}
+// parseConfig parses the output of `git config --get-regexp`. git allows a
+// key to be set multiple times (e.g. via repeated `git config --add
+// gitsign.oidcIssuers ...`), in which case it's emitted as one "key value"
+// line per value - so values are accumulated per key rather than
+// overwritten, letting applyOptionMap treat list-valued options
+// (gitsign.oidcIssuers, gitsign.timestampServerURLs) the same way regardless
+// of whether they came from git config or a file config.
+func parseConfig(r io.Reader) map[string][]string {
+	out := map[string][]string{}
 
 	s := bufio.NewScanner(r)
 	for s.Scan() {
@@ -160,42 +546,202 @@ func parseConfig(r io.Reader) map[string]string {
 		if len(data) < 2 {
 			continue
 		}
-		out[data[0]] = strings.Join(data[1:], " ")
+		key := data[0]
+		out[key] = append(out[key], strings.Join(data[1:], " "))
 	}
 	return out
 }
 
-func applyGitOptions(out *Config, cfg map[string]string) {
+// gitsignConfigFiles are checked, in order, for a repo-local config file.
+// The first one found is used.
+var gitsignConfigFiles = []string{".gitsign.yaml", ".gitsign.yml", ".gitsign.toml"}
+
+// loadFileConfig looks for a .gitsign.yaml/.gitsign.toml file at the repo
+// root (not the process's current working directory - git can invoke the
+// signing helper from any subdirectory of the repo) and merges any
+// gitsign.* keys it sets. This is not a full YAML/TOML parser: keys use the
+// same flattened dotted names as git config (e.g. "gitsign.rekorMode:
+// offline" or "gitsign.rekorMode = offline"), with no nesting, but unlike
+// git config directly, list-valued options like gitsign.oidcIssuers can be
+// given as an inline or indented list (see parseKeyValue).
+func loadFileConfig(out *Config) error {
+	root, err := repoRootFn()
+	if err != nil {
+		return fmt.Errorf("error resolving repo root: %w", err)
+	}
+
+	for _, name := range gitsignConfigFiles {
+		path := filepath.Join(root, name)
+		b, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", path, err)
+		}
+
+		cfg, err := parseKeyValue(bytes.NewReader(b))
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		applyOptionMap(out, cfg)
+		return nil
+	}
+	return nil
+}
+
+// gitRepoRoot returns the absolute path to the root of the current git
+// repository (https://git-scm.com/docs/git-rev-parse#Documentation/git-rev-parse.txt---show-toplevel),
+// so loadFileConfig finds the repo-local config file regardless of which
+// subdirectory gitsign was invoked from.
+func gitRepoRoot() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, stderr)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// parseKeyValue parses a subset of YAML/TOML into a flat map of dotted keys
+// to one or more values, skipping blank lines and comments. It does not
+// support nesting - only flattened keys like "gitsign.rekorMode: offline" -
+// but does support list values for options like gitsign.oidcIssuers, either
+// inline:
+//
+//	gitsign.oidcIssuers: [https://a.example.com, https://b.example.com]
+//
+// or as an indented block:
+//
+//	gitsign.oidcIssuers:
+//	  - https://a.example.com
+//	  - https://b.example.com
+func parseKeyValue(r io.Reader) (map[string][]string, error) {
+	out := map[string][]string{}
+
+	var pendingKey string
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		raw := s.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "-") && pendingKey != "" && raw != line {
+			item := unquote(strings.TrimSpace(strings.TrimPrefix(line, "-")))
+			out[pendingKey] = append(out[pendingKey], item)
+			continue
+		}
+		pendingKey = ""
+
+		sep := strings.IndexAny(line, ":=")
+		if sep < 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:sep])
+		value := strings.TrimSpace(line[sep+1:])
+
+		switch {
+		case value == "":
+			// No inline value: the following indented "- item" lines (if
+			// any) are this key's list value.
+			pendingKey = key
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				item = unquote(strings.TrimSpace(item))
+				if item == "" {
+					continue
+				}
+				out[key] = append(out[key], item)
+			}
+		default:
+			out[key] = append(out[key], unquote(value))
+		}
+	}
+	return out, s.Err()
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, `"'`)
+}
+
+// applyOptionMap applies a map of dotted config keys to one or more values
+// (as produced by parseConfig or parseKeyValue) onto a Config. Most options
+// are scalar and take the last value set for their key (consistent with how
+// git config treats repeated single-valued keys); a handful
+// (gitsign.oidcIssuers, gitsign.timestampServerURLs) are list-valued and
+// accumulate every value set for their key.
+func applyOptionMap(out *Config, cfg map[string][]string) {
+	last := func(v []string) string { return v[len(v)-1] }
+
 	for k, v := range cfg {
+		if len(v) == 0 {
+			continue
+		}
 		switch {
 		case strings.EqualFold(k, "user.name"):
-			out.CommitterName = v
+			out.CommitterName = last(v)
 		case strings.EqualFold(k, "user.email"):
-			out.CommitterEmail = v
+			out.CommitterEmail = last(v)
 		case strings.EqualFold(k, "gitsign.fulcio"):
-			out.Fulcio = v
+			out.Fulcio.URL = last(v)
 		case strings.EqualFold(k, "gitsign.fulcioRoot"):
-			out.FulcioRoot = v
+			out.Fulcio.Root = last(v)
+		case strings.EqualFold(k, "gitsign.fulcioProtocol"):
+			out.Fulcio.Protocol = last(v)
 		case strings.EqualFold(k, "gitsign.rekor"):
-			out.Rekor = v
+			out.Rekor.URL = last(v)
 		case strings.EqualFold(k, "gitsign.rekorMode"):
-			out.RekorMode = v
+			out.Rekor.Mode = last(v)
 		case strings.EqualFold(k, "gitsign.clientID"):
-			out.ClientID = v
+			out.OIDC.ClientID = last(v)
 		case strings.EqualFold(k, "gitsign.redirectURL"):
-			out.RedirectURL = v
+			out.OIDC.RedirectURL = last(v)
 		case strings.EqualFold(k, "gitsign.issuer"):
-			out.Issuer = v
-		case strings.EqualFold(k, "gitsign.logPath"):
-			out.LogPath = v
+			out.OIDC.Issuer = last(v)
+		case strings.EqualFold(k, "gitsign.oidcIssuers"):
+			out.OIDC.Issuers = append(out.OIDC.Issuers, v...)
 		case strings.EqualFold(k, "gitsign.connectorID"):
-			out.ConnectorID = v
+			out.OIDC.ConnectorID = last(v)
+		case strings.EqualFold(k, "gitsign.logPath"):
+			out.LogPath = last(v)
 		case strings.EqualFold(k, "gitsign.timestampServerURL"):
-			out.TimestampURL = v
+			out.TSA.URL = last(v)
+		case strings.EqualFold(k, "gitsign.timestampServerURLs"):
+			out.TSA.URLs = append(out.TSA.URLs, v...)
 		case strings.EqualFold(k, "gitsign.timestampCertChain"):
-			out.TimestampCert = v
+			out.TSA.CertChain = last(v)
+		case strings.EqualFold(k, "gitsign.timestampMode"):
+			out.TSA.Mode = last(v)
 		case strings.EqualFold(k, "gitsign.matchCommitter"):
-			out.MatchCommitter = strings.EqualFold(v, "true")
+			out.Policy.MatchCommitter = strings.EqualFold(last(v), "true")
+		case strings.EqualFold(k, "gitsign.trustedRoot"):
+			out.TrustedRoot.Path = last(v)
+		case strings.EqualFold(k, "gitsign.tufMirror"):
+			out.TrustedRoot.TUFMirror = last(v)
+		case strings.EqualFold(k, "gitsign.tufRoot"):
+			out.TrustedRoot.TUFRoot = last(v)
+		case strings.EqualFold(k, "gitsign.signingBackend"):
+			out.SigningBackend = SigningBackend(last(v))
+		case strings.EqualFold(k, "gitsign.kms"):
+			out.KMS.Key = last(v)
+		case strings.EqualFold(k, "gitsign.kmsCertChain"):
+			out.KMS.CertChain = last(v)
+		case strings.EqualFold(k, "gitsign.attestPredicateType"):
+			out.Attest.PredicateType = last(v)
+		case strings.EqualFold(k, "gitsign.attestOutput"):
+			out.Attest.Output = last(v)
+		case strings.EqualFold(k, "gitsign.caBundle"):
+			out.HTTP.CABundle = last(v)
+		case strings.EqualFold(k, "gitsign.mtlsCert"):
+			out.HTTP.MTLSCert = last(v)
+		case strings.EqualFold(k, "gitsign.mtlsKey"):
+			out.HTTP.MTLSKey = last(v)
 		}
 	}
 }
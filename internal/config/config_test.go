@@ -0,0 +1,388 @@
+// Copyright 2022 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// validConfig returns a Config that passes validate() unmodified, so tests
+// can tweak a single field to exercise one error path at a time.
+func validConfig() *Config {
+	cfg := defaultConfig()
+	cfg.SigningBackend = SigningBackendFulcioREST
+	return cfg
+}
+
+func TestValidate_OK(t *testing.T) {
+	if err := validate(validConfig()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_InvalidURL(t *testing.T) {
+	cfg := validConfig()
+	cfg.Fulcio.URL = "://not-a-url"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for invalid gitsign.fulcio URL")
+	}
+}
+
+func TestValidate_InvalidRekorMode(t *testing.T) {
+	cfg := validConfig()
+	cfg.Rekor.Mode = "sometimes"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for invalid gitsign.rekorMode")
+	}
+}
+
+func TestValidate_InvalidTimestampMode(t *testing.T) {
+	cfg := validConfig()
+	cfg.TSA.Mode = "whenever"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for invalid gitsign.timestampMode")
+	}
+}
+
+func TestValidate_InvalidSigningBackend(t *testing.T) {
+	cfg := validConfig()
+	cfg.SigningBackend = "carrier-pigeon"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for invalid gitsign.signingBackend")
+	}
+}
+
+func TestValidate_InvalidAttestOutput(t *testing.T) {
+	cfg := validConfig()
+	cfg.Attest.Output = "carrier-pigeon"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for invalid gitsign.attestOutput")
+	}
+}
+
+func TestValidate_MissingCertFile(t *testing.T) {
+	cfg := validConfig()
+	cfg.Fulcio.Root = filepath.Join(t.TempDir(), "does-not-exist.pem")
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for missing gitsign.fulcioRoot file")
+	}
+}
+
+func TestValidate_KMSRequiresCertChain(t *testing.T) {
+	cfg := validConfig()
+	cfg.KMS.Key = "awskms://key/1234"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error when gitsign.kms is set without gitsign.kmsCertChain")
+	}
+}
+
+func TestValidate_KMSNotYetImplemented(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "kms-cert.pem")
+	writeFile(t, certPath, "cert")
+
+	cfg := validConfig()
+	cfg.KMS.Key = "awskms://key/1234"
+	cfg.KMS.CertChain = certPath
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error: gitsign doesn't have a KMS client/CMS signer in this tree, so gitsign.kms must fail loudly rather than being silently ignored")
+	}
+}
+
+func TestValidate_FulcioGRPCNotYetImplemented(t *testing.T) {
+	cfg := validConfig()
+	cfg.SigningBackend = SigningBackendFulcioGRPC
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error: gitsign doesn't have a Fulcio v2 gRPC client in this tree, so gitsign.signingBackend=fulcio-grpc must fail loudly rather than being silently ignored")
+	}
+}
+
+func TestValidate_MTLSRequiresBothCertAndKey(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	writeFile(t, certPath, "cert")
+
+	cfg := validConfig()
+	cfg.HTTP.MTLSCert = certPath
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error when gitsign.mtlsCert is set without gitsign.mtlsKey")
+	}
+}
+
+func TestValidate_TrustedRootFileMissing(t *testing.T) {
+	cfg := validConfig()
+	cfg.TrustedRoot.Path = filepath.Join(t.TempDir(), "missing-trusted-root.json")
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for missing gitsign.trustedRoot file")
+	}
+}
+
+func TestValidate_TrustedRootFileInvalidJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "trusted-root.json")
+	writeFile(t, path, "not json")
+
+	cfg := validConfig()
+	cfg.TrustedRoot.Path = path
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for malformed gitsign.trustedRoot bundle")
+	}
+}
+
+func TestValidate_TUFMirrorRequiresTUFRoot(t *testing.T) {
+	cfg := validConfig()
+	cfg.TrustedRoot.TUFMirror = "https://tuf.example.com"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error when gitsign.tufMirror is set without gitsign.tufRoot")
+	}
+}
+
+func TestValidate_TUFMirrorNotYetImplemented(t *testing.T) {
+	cfg := validConfig()
+	cfg.TrustedRoot.TUFMirror = "https://tuf.example.com"
+	cfg.TrustedRoot.TUFRoot = "https://tuf.example.com/root.json"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error: gitsign doesn't vendor a TUF client, so tufMirror/tufRoot must fail loudly rather than being silently ignored")
+	}
+}
+
+func TestValidate_InvalidFulcioProtocol(t *testing.T) {
+	cfg := validConfig()
+	cfg.Fulcio.Protocol = "carrier-pigeon"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for invalid gitsign.fulcioProtocol")
+	}
+}
+
+func TestValidate_EmbedRequiresOfflineRekor(t *testing.T) {
+	cfg := validConfig()
+	cfg.TSA.Mode = "embed"
+	cfg.TSA.URL = "https://tsa.example.com"
+	cfg.Rekor.Mode = "online"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for gitsign.timestampMode=embed with gitsign.rekorMode=online")
+	}
+}
+
+func TestValidate_EmbedRequiresTSAURL(t *testing.T) {
+	cfg := validConfig()
+	cfg.TSA.Mode = "embed"
+	cfg.Rekor.Mode = "offline"
+	cfg.TSA.URL = ""
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for gitsign.timestampMode=embed without gitsign.timestampServerURL")
+	}
+}
+
+func TestValidate_EmbedNotYetImplemented(t *testing.T) {
+	cfg := validConfig()
+	cfg.TSA.Mode = "embed"
+	cfg.Rekor.Mode = "offline"
+	cfg.TSA.URL = "https://tsa.example.com"
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error: gitsign doesn't embed RFC3161 tokens as a CMS attribute, so timestampMode=embed must fail loudly rather than being silently ignored")
+	}
+}
+
+func TestValidate_VerifyEmbeddedRequiresTSATrustMaterial(t *testing.T) {
+	cfg := validConfig()
+	cfg.TSA.Mode = "verify-embedded"
+	cfg.TSA.CertChain = ""
+	cfg.TrustedRoot.Path = ""
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for gitsign.timestampMode=verify-embedded without a TSA cert chain or trusted root")
+	}
+}
+
+func TestValidate_VerifyEmbeddedNotYetImplemented(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trusted-root.json")
+	writeFile(t, path, `{}`)
+
+	cfg := validConfig()
+	cfg.TSA.Mode = "verify-embedded"
+	cfg.TSA.CertChain = ""
+	cfg.TrustedRoot.Path = path // satisfies the trust-material prerequisite check
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error: gitsign doesn't verify embedded RFC3161 tokens, so timestampMode=verify-embedded must fail loudly rather than being silently ignored")
+	}
+}
+
+func TestApplyEnv_InfersGRPCFromURLScheme(t *testing.T) {
+	out := defaultConfig()
+	out.Fulcio.URL = "grpcs://fulcio.sigstore.dev"
+	applyEnv(out)
+
+	if out.SigningBackend != SigningBackendFulcioGRPC {
+		t.Fatalf("expected inferred backend %q, got %q", SigningBackendFulcioGRPC, out.SigningBackend)
+	}
+}
+
+func TestApplyEnv_InfersKMSFromKey(t *testing.T) {
+	out := defaultConfig()
+	out.KMS.Key = "awskms://key/1234"
+	applyEnv(out)
+
+	if out.SigningBackend != SigningBackendKMS {
+		t.Fatalf("expected inferred backend %q, got %q", SigningBackendKMS, out.SigningBackend)
+	}
+}
+
+func TestApplyEnv_DefaultsToFulcioRESTWhenUnset(t *testing.T) {
+	out := defaultConfig()
+	applyEnv(out)
+
+	if out.SigningBackend != SigningBackendFulcioREST {
+		t.Fatalf("expected default backend %q, got %q", SigningBackendFulcioREST, out.SigningBackend)
+	}
+}
+
+// TestApplyEnv_ExplicitFulcioRESTNotClobbered guards against the bug where
+// SigningBackendFulcioREST was used as the "not yet chosen" sentinel, so an
+// explicit gitsign.signingBackend = fulcio-rest next to a leftover KMS key
+// or grpcs:// Fulcio URL got silently overridden back to grpc/kms.
+func TestApplyEnv_ExplicitFulcioRESTNotClobbered(t *testing.T) {
+	out := defaultConfig()
+	out.SigningBackend = SigningBackendFulcioREST // as if set explicitly via git config
+	out.Fulcio.URL = "grpcs://fulcio.sigstore.dev"
+	out.KMS.Key = "awskms://key/1234"
+	applyEnv(out)
+
+	if out.SigningBackend != SigningBackendFulcioREST {
+		t.Fatalf("explicit gitsign.signingBackend was clobbered: got %q", out.SigningBackend)
+	}
+}
+
+// TestLoadFileConfig_ResolvesRepoRoot guards against the bug where
+// loadFileConfig read .gitsign.yaml/.gitsign.toml relative to the process's
+// current working directory instead of the repo root, so the file was
+// silently skipped whenever git invoked gitsign from a subdirectory.
+func TestLoadFileConfig_ResolvesRepoRoot(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitsign.yaml"), "gitsign.rekorMode: offline\n")
+
+	orig := repoRootFn
+	repoRootFn = func() (string, error) { return root, nil }
+	defer func() { repoRootFn = orig }()
+
+	out := defaultConfig()
+	if err := loadFileConfig(out); err != nil {
+		t.Fatalf("error loading file config: %v", err)
+	}
+	if out.Rekor.Mode != "offline" {
+		t.Fatalf("expected gitsign.rekorMode=offline from repo-root config file, got %q", out.Rekor.Mode)
+	}
+}
+
+func TestLoadFileConfig_RepoRootResolutionError(t *testing.T) {
+	orig := repoRootFn
+	repoRootFn = func() (string, error) { return "", fmt.Errorf("not a git repository") }
+	defer func() { repoRootFn = orig }()
+
+	if err := loadFileConfig(defaultConfig()); err == nil {
+		t.Fatal("expected error when repo root can't be resolved")
+	}
+}
+
+func TestLoadFileConfig_InlineListValue(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitsign.yaml"),
+		"gitsign.oidcIssuers: [https://a.example.com, https://b.example.com]\n")
+
+	orig := repoRootFn
+	repoRootFn = func() (string, error) { return root, nil }
+	defer func() { repoRootFn = orig }()
+
+	out := defaultConfig()
+	if err := loadFileConfig(out); err != nil {
+		t.Fatalf("error loading file config: %v", err)
+	}
+	want := []string{"https://a.example.com", "https://b.example.com"}
+	if !reflect.DeepEqual(out.OIDC.Issuers, want) {
+		t.Fatalf("expected gitsign.oidcIssuers %v, got %v", want, out.OIDC.Issuers)
+	}
+}
+
+func TestLoadFileConfig_IndentedListValue(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitsign.yaml"), strings.Join([]string{
+		"gitsign.timestampServerURLs:",
+		"  - https://tsa-a.example.com",
+		"  - https://tsa-b.example.com",
+		"gitsign.rekorMode: offline",
+		"",
+	}, "\n"))
+
+	orig := repoRootFn
+	repoRootFn = func() (string, error) { return root, nil }
+	defer func() { repoRootFn = orig }()
+
+	out := defaultConfig()
+	if err := loadFileConfig(out); err != nil {
+		t.Fatalf("error loading file config: %v", err)
+	}
+	want := []string{"https://tsa-a.example.com", "https://tsa-b.example.com"}
+	if !reflect.DeepEqual(out.TSA.URLs, want) {
+		t.Fatalf("expected gitsign.timestampServerURLs %v, got %v", want, out.TSA.URLs)
+	}
+	if out.Rekor.Mode != "offline" {
+		t.Fatalf("expected gitsign.rekorMode=offline to still apply after a list value, got %q", out.Rekor.Mode)
+	}
+}
+
+func TestApplyOptionMap_RepeatedGitConfigKeyAccumulates(t *testing.T) {
+	cfg := map[string][]string{
+		"gitsign.oidcIssuers": {"https://a.example.com", "https://b.example.com"},
+		"gitsign.rekorMode":   {"online", "offline"}, // repeated scalar key: last wins
+	}
+
+	out := defaultConfig()
+	applyOptionMap(out, cfg)
+
+	want := []string{"https://a.example.com", "https://b.example.com"}
+	if !reflect.DeepEqual(out.OIDC.Issuers, want) {
+		t.Fatalf("expected gitsign.oidcIssuers %v, got %v", want, out.OIDC.Issuers)
+	}
+	if out.Rekor.Mode != "offline" {
+		t.Fatalf("expected last value to win for repeated scalar key gitsign.rekorMode, got %q", out.Rekor.Mode)
+	}
+}
+
+func TestValidate_InvalidOIDCIssuersEntry(t *testing.T) {
+	cfg := validConfig()
+	cfg.OIDC.Issuers = []string{"https://ok.example.com", "://not-a-url"}
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for invalid gitsign.oidcIssuers entry")
+	}
+}
+
+func TestValidate_InvalidTimestampServerURLsEntry(t *testing.T) {
+	cfg := validConfig()
+	cfg.TSA.URLs = []string{"https://ok.example.com", "://not-a-url"}
+	if err := validate(cfg); err == nil {
+		t.Fatal("expected error for invalid gitsign.timestampServerURLs entry")
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("error writing %s: %v", path, err)
+	}
+}
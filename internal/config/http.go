@@ -0,0 +1,112 @@
+// Copyright 2022 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// version is the gitsign release version. Set via -ldflags at build time.
+var version = "unknown"
+
+// HTTPTransport returns an *http.Client for use with all outbound calls to
+// Fulcio/Rekor/TSA/OIDC. The returned client identifies itself via a
+// User-Agent header (matching what cosign does, see sigstore/cosign#1131,
+// so server operators can identify and rate-limit gitsign traffic), honors
+// HTTPS_PROXY/NO_PROXY, and applies the HTTPConfig CABundle/MTLSCert/MTLSKey
+// options.
+func (c *Config) HTTPTransport() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig := transport.TLSClientConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+
+	if c.HTTP.CABundle != "" {
+		pool, err := loadCertPool(c.HTTP.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("error loading gitsign.caBundle: %w", err)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.HTTP.MTLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(c.HTTP.MTLSCert, c.HTTP.MTLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading gitsign.mtlsCert/gitsign.mtlsKey: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{
+		Transport: &userAgentTransport{
+			next:      transport,
+			userAgent: fmt.Sprintf("gitsign/%s (%s)", version, gitVersion()),
+		},
+	}, nil
+}
+
+// userAgentTransport wraps an http.RoundTripper to set a User-Agent header
+// on every outbound request.
+type userAgentTransport struct {
+	next      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.next.RoundTrip(req)
+}
+
+// loadCertPool reads the PEM bundle at path and returns it added to a copy
+// of the system root CA pool, so gitsign.caBundle trusts its own CAs *in
+// addition to* the system roots (per the CABundle doc comment), rather than
+// replacing the system roots and breaking TLS to any endpoint not signed by
+// the bundle.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if ok := pool.AppendCertsFromPEM(pem); !ok {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// gitVersion returns the output of `git version`, for inclusion in the
+// User-Agent header. Falls back to "unknown" if git can't be run.
+func gitVersion() string {
+	out, err := exec.Command("git", "version").Output()
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPTransport_SetsUserAgent(t *testing.T) {
+	var gotUA string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	cfg := validConfig()
+	client, err := cfg.HTTPTransport()
+	if err != nil {
+		t.Fatalf("error building HTTP transport: %v", err)
+	}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("error making request: %v", err)
+	}
+	resp.Body.Close()
+
+	if !strings.HasPrefix(gotUA, "gitsign/") {
+		t.Fatalf("expected User-Agent to start with %q, got %q", "gitsign/", gotUA)
+	}
+}
+
+func TestHTTPTransport_LoadsCABundle(t *testing.T) {
+	certPEM, _ := writeSelfSignedPair(t, "ca.pem", "")
+
+	cfg := validConfig()
+	cfg.HTTP.CABundle = certPEM
+	client, err := cfg.HTTPTransport()
+	if err != nil {
+		t.Fatalf("error building HTTP transport: %v", err)
+	}
+	if client.Transport == nil {
+		t.Fatal("expected non-nil transport")
+	}
+}
+
+// TestHTTPTransport_CABundlePreservesSystemRoots guards against the bug
+// where loadCertPool built a bare x509.NewCertPool() containing only the
+// gitsign.caBundle certs, silently dropping the system root CAs the
+// CABundle doc comment promises to trust "in addition to."
+func TestHTTPTransport_CABundlePreservesSystemRoots(t *testing.T) {
+	sysPool, err := x509.SystemCertPool()
+	if err != nil || sysPool == nil || len(sysPool.Subjects()) == 0 { //nolint:staticcheck
+		t.Skip("no usable system cert pool in this environment")
+	}
+	wantSystemCerts := len(sysPool.Subjects()) //nolint:staticcheck
+
+	certPEM, _ := writeSelfSignedPair(t, "ca.pem", "")
+
+	pool, err := loadCertPool(certPEM)
+	if err != nil {
+		t.Fatalf("error loading cert pool: %v", err)
+	}
+
+	got := len(pool.Subjects()) //nolint:staticcheck
+	if got != wantSystemCerts+1 {
+		t.Fatalf("expected system roots (%d) plus the configured bundle cert (1) = %d, got %d", wantSystemCerts, wantSystemCerts+1, got)
+	}
+}
+
+func TestHTTPTransport_MissingCABundle(t *testing.T) {
+	cfg := validConfig()
+	cfg.HTTP.CABundle = filepath.Join(t.TempDir(), "does-not-exist.pem")
+	if _, err := cfg.HTTPTransport(); err == nil {
+		t.Fatal("expected error for missing gitsign.caBundle file")
+	}
+}
+
+func TestHTTPTransport_MalformedCABundle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ca.pem")
+	writeFile(t, path, "not a pem bundle")
+
+	cfg := validConfig()
+	cfg.HTTP.CABundle = path
+	if _, err := cfg.HTTPTransport(); err == nil {
+		t.Fatal("expected error for malformed gitsign.caBundle file")
+	}
+}
+
+func TestHTTPTransport_LoadsMTLSPair(t *testing.T) {
+	certPEM, keyPEM := writeSelfSignedPair(t, "mtls-cert.pem", "mtls-key.pem")
+
+	cfg := validConfig()
+	cfg.HTTP.MTLSCert = certPEM
+	cfg.HTTP.MTLSKey = keyPEM
+	if _, err := cfg.HTTPTransport(); err != nil {
+		t.Fatalf("error loading matched gitsign.mtlsCert/gitsign.mtlsKey: %v", err)
+	}
+}
+
+func TestHTTPTransport_MismatchedMTLSPair(t *testing.T) {
+	certPEM, _ := writeSelfSignedPair(t, "a-cert.pem", "a-key.pem")
+	_, keyPEM := writeSelfSignedPair(t, "b-cert.pem", "b-key.pem")
+
+	cfg := validConfig()
+	cfg.HTTP.MTLSCert = certPEM
+	cfg.HTTP.MTLSKey = keyPEM
+	if _, err := cfg.HTTPTransport(); err == nil {
+		t.Fatal("expected error for gitsign.mtlsCert/gitsign.mtlsKey from different key pairs")
+	}
+}
+
+// writeSelfSignedPair generates a self-signed certificate/key pair and
+// writes whichever of certName/keyName are non-empty as PEM files in a
+// fresh temp dir, returning their paths (empty if the corresponding name
+// was empty).
+func writeSelfSignedPair(t *testing.T, certName, keyName string) (certPath, keyPath string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gitsign-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("error marshaling key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	dir := t.TempDir()
+	if certName != "" {
+		certPath = filepath.Join(dir, certName)
+		writeFile(t, certPath, string(certPEM))
+	}
+	if keyName != "" {
+		keyPath = filepath.Join(dir, keyName)
+		writeFile(t, keyPath, string(keyPEM))
+	}
+	return certPath, keyPath
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// TrustedRootBundle is a simplified representation of the PEM material held
+// in a Sigstore trusted root JSON file (as produced by TUF via
+// cosign/sigstore-go): only the Fulcio CA chain(s) and TSA cert chain(s) are
+// parsed. The Rekor transparency log public keys, CT log public keys, and
+// the validity time ranges in the file are not read.
+type TrustedRootBundle struct {
+	CertificateAuthorities []struct {
+		CertChain struct {
+			Certificates []struct {
+				RawBytes []byte `json:"rawBytes"`
+			} `json:"certificates"`
+		} `json:"certChain"`
+	} `json:"certificateAuthorities"`
+	TimestampAuthorities []struct {
+		CertChain struct {
+			Certificates []struct {
+				RawBytes []byte `json:"rawBytes"`
+			} `json:"certificates"`
+		} `json:"certChain"`
+	} `json:"timestampAuthorities"`
+}
+
+// LoadTrustedRootBundle reads and parses a Sigstore trusted root JSON file
+// from path.
+func LoadTrustedRootBundle(path string) (*TrustedRootBundle, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &TrustedRootBundle{}
+	if err := json.Unmarshal(b, out); err != nil {
+		return nil, fmt.Errorf("invalid trusted root bundle: %w", err)
+	}
+	return out, nil
+}
+
+// FulcioRootPEM returns the concatenated PEM bytes of the Fulcio CA chain(s)
+// in the bundle.
+func (b *TrustedRootBundle) FulcioRootPEM() []byte {
+	var out []byte
+	for _, ca := range b.CertificateAuthorities {
+		for _, cert := range ca.CertChain.Certificates {
+			out = append(out, derToPEM(cert.RawBytes)...)
+		}
+	}
+	return out
+}
+
+// TSACertChainPEM returns the concatenated PEM bytes of the TSA cert
+// chain(s) in the bundle.
+func (b *TrustedRootBundle) TSACertChainPEM() []byte {
+	var out []byte
+	for _, tsa := range b.TimestampAuthorities {
+		for _, cert := range tsa.CertChain.Certificates {
+			out = append(out, derToPEM(cert.RawBytes)...)
+		}
+	}
+	return out
+}
+
+// derToPEM encodes a single DER certificate (as unmarshaled from a
+// trusted_root.json "rawBytes" field, which json.Unmarshal already
+// base64-decodes into raw DER) as a PEM "CERTIFICATE" block.
+func derToPEM(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// ResolveFulcioRoot returns the PEM bytes verification code should trust as
+// the Fulcio CA root: the trusted root bundle's chain if gitsign.trustedRoot
+// is set, falling back to gitsign.fulcioRoot, and nil if neither is
+// configured. Note: this tree doesn't include a
+// Fulcio certificate verification path yet (see internal/attest for the one
+// signing/storage flow that is implemented) - ResolveFulcioRoot resolves the
+// trust material such a path would consume, but nothing calls it yet.
+func (c *Config) ResolveFulcioRoot() ([]byte, error) {
+	if c.TrustedRoot.Path != "" {
+		bundle, err := LoadTrustedRootBundle(c.TrustedRoot.Path)
+		if err != nil {
+			return nil, fmt.Errorf("error loading gitsign.trustedRoot: %w", err)
+		}
+		return bundle.FulcioRootPEM(), nil
+	}
+	if c.Fulcio.Root != "" {
+		return os.ReadFile(c.Fulcio.Root)
+	}
+	return nil, nil
+}
+
+// ResolveTSACertChain returns the PEM bytes verification code should trust
+// for RFC3161 timestamp verification: the trusted root bundle's TSA chain if
+// gitsign.trustedRoot is set, falling back to gitsign.timestampCertChain.
+func (c *Config) ResolveTSACertChain() ([]byte, error) {
+	if c.TrustedRoot.Path != "" {
+		bundle, err := LoadTrustedRootBundle(c.TrustedRoot.Path)
+		if err != nil {
+			return nil, fmt.Errorf("error loading gitsign.trustedRoot: %w", err)
+		}
+		return bundle.TSACertChainPEM(), nil
+	}
+	if c.TSA.CertChain != "" {
+		return os.ReadFile(c.TSA.CertChain)
+	}
+	return nil, nil
+}
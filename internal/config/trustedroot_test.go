@@ -0,0 +1,240 @@
+// Copyright 2022 The Sigstore Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// selfSignedDER returns the DER bytes of a minimal self-signed certificate,
+// i.e. what a trusted_root.json "rawBytes" field holds once json.Unmarshal
+// has base64-decoded it.
+func selfSignedDER(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gitsign-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+	return der
+}
+
+// trustedRootJSON builds a minimal trusted_root.json with a single Fulcio CA
+// cert and a single TSA cert, both holding der as their "rawBytes" (which
+// encoding/json base64-encodes on Marshal, round-tripping to raw DER on the
+// Unmarshal side in LoadTrustedRootBundle, exactly as the real format does).
+func trustedRootJSON(t *testing.T, der []byte) []byte {
+	t.Helper()
+
+	type certificate struct {
+		RawBytes []byte `json:"rawBytes"`
+	}
+	type certChain struct {
+		Certificates []certificate `json:"certificates"`
+	}
+	doc := struct {
+		CertificateAuthorities []struct {
+			CertChain certChain `json:"certChain"`
+		} `json:"certificateAuthorities"`
+		TimestampAuthorities []struct {
+			CertChain certChain `json:"certChain"`
+		} `json:"timestampAuthorities"`
+	}{}
+	doc.CertificateAuthorities = append(doc.CertificateAuthorities, struct {
+		CertChain certChain `json:"certChain"`
+	}{CertChain: certChain{Certificates: []certificate{{RawBytes: der}}}})
+	doc.TimestampAuthorities = append(doc.TimestampAuthorities, struct {
+		CertChain certChain `json:"certChain"`
+	}{CertChain: certChain{Certificates: []certificate{{RawBytes: der}}}})
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("error marshaling trusted root bundle: %v", err)
+	}
+	return b
+}
+
+func TestTrustedRootBundle_FulcioRootPEM_AppendableToCertPool(t *testing.T) {
+	der := selfSignedDER(t)
+	path := filepath.Join(t.TempDir(), "trusted-root.json")
+	writeFile(t, path, string(trustedRootJSON(t, der)))
+
+	bundle, err := LoadTrustedRootBundle(path)
+	if err != nil {
+		t.Fatalf("error loading trusted root bundle: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(bundle.FulcioRootPEM()); !ok {
+		t.Fatal("AppendCertsFromPEM rejected FulcioRootPEM output: not valid PEM-encoded certificates")
+	}
+}
+
+func TestTrustedRootBundle_TSACertChainPEM_AppendableToCertPool(t *testing.T) {
+	der := selfSignedDER(t)
+	path := filepath.Join(t.TempDir(), "trusted-root.json")
+	writeFile(t, path, string(trustedRootJSON(t, der)))
+
+	bundle, err := LoadTrustedRootBundle(path)
+	if err != nil {
+		t.Fatalf("error loading trusted root bundle: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(bundle.TSACertChainPEM()); !ok {
+		t.Fatal("AppendCertsFromPEM rejected TSACertChainPEM output: not valid PEM-encoded certificates")
+	}
+}
+
+func TestResolveFulcioRoot_PrefersTrustedRootBundle(t *testing.T) {
+	der := selfSignedDER(t)
+	dir := t.TempDir()
+
+	bundlePath := filepath.Join(dir, "trusted-root.json")
+	writeFile(t, bundlePath, string(trustedRootJSON(t, der)))
+
+	rootPath := filepath.Join(dir, "fulcio-root.pem")
+	writeFile(t, rootPath, "not used, the bundle should win")
+
+	cfg := &Config{TrustedRoot: TrustedRootConfig{Path: bundlePath}, Fulcio: FulcioConfig{Root: rootPath}}
+
+	got, err := cfg.ResolveFulcioRoot()
+	if err != nil {
+		t.Fatalf("ResolveFulcioRoot: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(got); !ok {
+		t.Fatal("ResolveFulcioRoot returned bytes that aren't valid PEM-encoded certificates")
+	}
+}
+
+func TestResolveFulcioRoot_FallsBackToFulcioRootFile(t *testing.T) {
+	certPath, _ := writeSelfSignedPair(t, "fulcio-root.pem", "")
+
+	cfg := &Config{Fulcio: FulcioConfig{Root: certPath}}
+
+	got, err := cfg.ResolveFulcioRoot()
+	if err != nil {
+		t.Fatalf("ResolveFulcioRoot: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(got); !ok {
+		t.Fatal("ResolveFulcioRoot returned bytes that aren't valid PEM-encoded certificates")
+	}
+}
+
+func TestResolveFulcioRoot_NilWhenUnconfigured(t *testing.T) {
+	cfg := &Config{}
+
+	got, err := cfg.ResolveFulcioRoot()
+	if err != nil {
+		t.Fatalf("ResolveFulcioRoot: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("expected nil PEM bytes when neither gitsign.trustedRoot nor gitsign.fulcioRoot is set, got %q", got)
+	}
+}
+
+func TestResolveFulcioRoot_PropagatesBundleLoadError(t *testing.T) {
+	cfg := &Config{TrustedRoot: TrustedRootConfig{Path: filepath.Join(t.TempDir(), "does-not-exist.json")}}
+
+	if _, err := cfg.ResolveFulcioRoot(); err == nil {
+		t.Fatal("expected error when gitsign.trustedRoot points at a missing file")
+	}
+}
+
+func TestResolveTSACertChain_PrefersTrustedRootBundle(t *testing.T) {
+	der := selfSignedDER(t)
+	dir := t.TempDir()
+
+	bundlePath := filepath.Join(dir, "trusted-root.json")
+	writeFile(t, bundlePath, string(trustedRootJSON(t, der)))
+
+	chainPath := filepath.Join(dir, "tsa-chain.pem")
+	writeFile(t, chainPath, "not used, the bundle should win")
+
+	cfg := &Config{TrustedRoot: TrustedRootConfig{Path: bundlePath}, TSA: TSAConfig{CertChain: chainPath}}
+
+	got, err := cfg.ResolveTSACertChain()
+	if err != nil {
+		t.Fatalf("ResolveTSACertChain: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(got); !ok {
+		t.Fatal("ResolveTSACertChain returned bytes that aren't valid PEM-encoded certificates")
+	}
+}
+
+func TestResolveTSACertChain_FallsBackToTSACertChainFile(t *testing.T) {
+	certPath, _ := writeSelfSignedPair(t, "tsa-chain.pem", "")
+
+	cfg := &Config{TSA: TSAConfig{CertChain: certPath}}
+
+	got, err := cfg.ResolveTSACertChain()
+	if err != nil {
+		t.Fatalf("ResolveTSACertChain: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(got); !ok {
+		t.Fatal("ResolveTSACertChain returned bytes that aren't valid PEM-encoded certificates")
+	}
+}
+
+func TestResolveTSACertChain_NilWhenUnconfigured(t *testing.T) {
+	cfg := &Config{}
+
+	got, err := cfg.ResolveTSACertChain()
+	if err != nil {
+		t.Fatalf("ResolveTSACertChain: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("expected nil PEM bytes when neither gitsign.trustedRoot nor gitsign.timestampCertChain is set, got %q", got)
+	}
+}
+
+func TestResolveTSACertChain_PropagatesBundleLoadError(t *testing.T) {
+	cfg := &Config{TrustedRoot: TrustedRootConfig{Path: filepath.Join(t.TempDir(), "does-not-exist.json")}}
+
+	if _, err := cfg.ResolveTSACertChain(); err == nil {
+		t.Fatal("expected error when gitsign.trustedRoot points at a missing file")
+	}
+}